@@ -0,0 +1,133 @@
+package redis
+
+// Algorithm identifies the rate-limiting algorithm used by a Store to decide
+// whether a request is allowed, and how the counter/state is represented in
+// Redis.
+type Algorithm int
+
+const (
+	// FixedWindow is the default algorithm: a single counter per key,
+	// incremented with INCR and expired with the rate period. This is the
+	// historical behavior of this package, implemented with WATCH/MULTI
+	// optimistic locking.
+	FixedWindow Algorithm = iota
+	// SlidingWindow keeps a ZSET of request timestamps per key and evicts
+	// entries older than the rate period on every request, giving an exact
+	// sliding-window count instead of a fixed-window approximation.
+	SlidingWindow
+	// TokenBucket stores a virtual theoretical arrival time (TAT) per key
+	// and grants a request if the TAT does not exceed the allowed burst
+	// window, following the GCRA algorithm.
+	TokenBucket
+	// GCRA is an alias of TokenBucket: it is the algorithm used to implement
+	// the token bucket semantics through the Generic Cell Rate Algorithm.
+	GCRA = TokenBucket
+)
+
+// slidingWindowScript evicts timestamps older than the rate period, counts
+// the remaining entries, and if under the limit records the current request.
+//
+// KEYS[1] = the counter key
+// ARGV[1] = now, in nanoseconds
+// ARGV[2] = period, in nanoseconds
+// ARGV[3] = limit
+// ARGV[4] = unique member to record for this request
+//
+// Returns {allowed (0/1), count, oldest remaining timestamp or -1}.
+const slidingWindowScript = `
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local member = ARGV[4]
+
+redis.call("ZREMRANGEBYSCORE", key, 0, now - period)
+
+local count = redis.call("ZCARD", key)
+local allowed = 0
+
+if count < limit then
+	redis.call("ZADD", key, now, member)
+	redis.call("PEXPIRE", key, math.ceil(period / 1e6))
+	allowed = 1
+	count = count + 1
+end
+
+local oldest = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+local oldestTimestamp = -1
+if #oldest > 0 then
+	oldestTimestamp = oldest[2]
+end
+
+return {allowed, count, oldestTimestamp}
+`
+
+// gcraScript implements the Generic Cell Rate Algorithm: it stores the
+// theoretical arrival time (tat) of the key in a hash and allows the request
+// if the updated tat does not exceed now plus the configured burst window.
+//
+// KEYS[1] = the counter key
+// ARGV[1] = now, in nanoseconds
+// ARGV[2] = emission interval (period / limit), in nanoseconds
+// ARGV[3] = burst window (period), in nanoseconds
+// ARGV[4] = period, in nanoseconds, used for key expiration
+//
+// Returns {allowed (0/1), remaining, retry_after_ms, reset_ms}.
+const gcraScript = `
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local emission = tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+local period = tonumber(ARGV[4])
+
+local tat = tonumber(redis.call("HGET", key, "tat"))
+if tat == nil or tat < now then
+	tat = now
+end
+
+local newTat = tat + emission
+local allowAt = newTat - burst
+
+local allowed = 0
+local retryAfter = -1
+
+if allowAt <= now then
+	redis.call("HSET", key, "tat", newTat)
+	redis.call("PEXPIRE", key, math.ceil(period / 1e6))
+	allowed = 1
+	tat = newTat
+else
+	retryAfter = math.ceil((allowAt - now) / 1e6)
+end
+
+local remaining = math.floor((burst - (tat - now)) / emission)
+if remaining < 0 then
+	remaining = 0
+end
+
+local resetMs = math.ceil((tat - now) / 1e6)
+
+return {allowed, remaining, retryAfter, resetMs}
+`
+
+// incrByScript adds delta to the counter and, only if the key didn't already
+// have an expiration (i.e. it was just created by this INCRBY), sets one.
+//
+// KEYS[1] = the counter key
+// ARGV[1] = delta
+// ARGV[2] = period, in milliseconds
+//
+// Returns the updated count.
+const incrByScript = `
+local key = KEYS[1]
+local delta = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+
+local count = redis.call("INCRBY", key, delta)
+
+if redis.call("PTTL", key) < 0 then
+	redis.call("PEXPIRE", key, period)
+end
+
+return count
+`
@@ -0,0 +1,214 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	libredis "github.com/go-redis/redis/v8"
+
+	"github.com/ulule/limiter/v3"
+)
+
+// fakeClient is a minimal Client implementation used to unit-test the
+// script-loading and algorithm-dispatch logic without a real Redis server.
+type fakeClient struct {
+	scriptLoadCalls int32
+	// evalShaResult, when set, is returned verbatim by EvalSha instead of
+	// the default int64(0), letting tests simulate a specific Lua script
+	// reply without a real Redis server.
+	evalShaResult interface{}
+
+	mu       sync.Mutex
+	evalArgs [][]interface{}
+}
+
+func (c *fakeClient) Get(ctx context.Context, key string) *libredis.StringCmd {
+	return libredis.NewStringCmd(ctx)
+}
+
+func (c *fakeClient) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *libredis.StatusCmd {
+	return libredis.NewStatusCmd(ctx)
+}
+
+func (c *fakeClient) Watch(ctx context.Context, handler func(*libredis.Tx) error, keys ...string) error {
+	return nil
+}
+
+func (c *fakeClient) Del(ctx context.Context, keys ...string) *libredis.IntCmd {
+	return libredis.NewIntCmd(ctx)
+}
+
+func (c *fakeClient) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *libredis.BoolCmd {
+	return libredis.NewBoolCmd(ctx)
+}
+
+func (c *fakeClient) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *libredis.Cmd {
+	cmd := libredis.NewCmd(ctx)
+	cmd.SetVal(int64(0))
+	return cmd
+}
+
+func (c *fakeClient) EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *libredis.Cmd {
+	c.mu.Lock()
+	c.evalArgs = append(c.evalArgs, args)
+	c.mu.Unlock()
+
+	cmd := libredis.NewCmd(ctx)
+	if c.evalShaResult != nil {
+		cmd.SetVal(c.evalShaResult)
+	} else {
+		cmd.SetVal(int64(0))
+	}
+	return cmd
+}
+
+func (c *fakeClient) ScriptLoad(ctx context.Context, script string) *libredis.StringCmd {
+	atomic.AddInt32(&c.scriptLoadCalls, 1)
+	cmd := libredis.NewStringCmd(ctx)
+	cmd.SetVal("deadbeef")
+	return cmd
+}
+
+func (c *fakeClient) Publish(ctx context.Context, channel string, message interface{}) *libredis.IntCmd {
+	return libredis.NewIntCmd(ctx)
+}
+
+func (c *fakeClient) PSubscribe(ctx context.Context, channels ...string) *libredis.PubSub {
+	return nil
+}
+
+func TestLoadScriptIsRaceFree(t *testing.T) {
+	client := &fakeClient{}
+	store := &Store{client: client}
+
+	var sha string
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := store.loadScript(context.Background(), "return 1", &sha); err != nil {
+				t.Errorf("loadScript: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls := atomic.LoadInt32(&client.scriptLoadCalls); calls != 1 {
+		t.Fatalf("expected ScriptLoad to be called once, got %d", calls)
+	}
+}
+
+func TestGetGCRAZeroLimitDoesNotPanic(t *testing.T) {
+	store := &Store{client: &fakeClient{}}
+
+	lctx, err := store.getGCRA(context.Background(), "key", limiter.Rate{Period: time.Second, Limit: 0})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !lctx.Reached {
+		t.Fatalf("expected a zero limit to report the request as rejected")
+	}
+}
+
+// TestGetGCRADoesNotDoubleCountAllowedRequest simulates the gcraScript
+// reply for the last legitimately-allowed request of a limit=5 burst
+// (allowed=1, remaining=0, i.e. the bucket is now exactly full) and checks
+// that the request isn't reported as having exceeded the limit: count must
+// equal rate.Limit, not rate.Limit+1.
+func TestGetGCRADoesNotDoubleCountAllowedRequest(t *testing.T) {
+	client := &fakeClient{evalShaResult: []interface{}{int64(1), int64(0), int64(-1), int64(0)}}
+	store := &Store{client: client, scripts: scriptSHAs{gcra: "deadbeef"}}
+
+	lctx, err := store.getGCRA(context.Background(), "key", limiter.Rate{Period: time.Second, Limit: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lctx.Limit-lctx.Remaining != 5 {
+		t.Fatalf("count = %d, want 5 (the allowed request must not be double-counted)", lctx.Limit-lctx.Remaining)
+	}
+	if lctx.Reached {
+		t.Fatalf("an allowed request must not be reported as having reached the limit")
+	}
+}
+
+// TestGetSlidingWindowMembersAreUnique exercises many concurrent requests
+// and checks the ZADD member passed to the script is unique for every one
+// of them, even though they may all share the same time.Now() nanosecond.
+func TestGetSlidingWindowMembersAreUnique(t *testing.T) {
+	client := &fakeClient{evalShaResult: []interface{}{int64(1), int64(1), int64(-1)}}
+	store := &Store{client: client, scripts: scriptSHAs{slidingWindow: "deadbeef"}}
+	rate := limiter.Rate{Period: time.Minute, Limit: 1000}
+
+	const calls = 100
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := store.getSlidingWindow(context.Background(), "key", rate); err != nil {
+				t.Errorf("getSlidingWindow: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[interface{}]bool, calls)
+	for _, args := range client.evalArgs {
+		member := args[len(args)-1]
+		if seen[member] {
+			t.Fatalf("duplicate ZADD member %v: two requests would collide and undercount", member)
+		}
+		seen[member] = true
+	}
+	if len(seen) != calls {
+		t.Fatalf("expected %d distinct members, got %d", calls, len(seen))
+	}
+}
+
+func TestToInt64(t *testing.T) {
+	cases := []struct {
+		in      interface{}
+		want    int64
+		wantErr bool
+	}{
+		{int64(42), 42, false},
+		{"42", 42, false},
+		{"not-a-number", 0, true},
+		{3.14, 0, true},
+	}
+
+	for _, c := range cases {
+		got, err := toInt64(c.in)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("toInt64(%v): expected an error", c.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("toInt64(%v): unexpected error: %v", c.in, err)
+		}
+		if got != c.want {
+			t.Errorf("toInt64(%v) = %d, want %d", c.in, got, c.want)
+		}
+	}
+}
+
+func TestIsNoScriptErr(t *testing.T) {
+	if isNoScriptErr(nil) {
+		t.Error("isNoScriptErr(nil) should be false")
+	}
+	if !isNoScriptErr(&noScriptErr{}) {
+		t.Error("expected a NOSCRIPT error to be detected")
+	}
+}
+
+type noScriptErr struct{}
+
+func (e *noScriptErr) Error() string {
+	return "NOSCRIPT No matching script. Please use EVAL."
+}
@@ -0,0 +1,126 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	libredis "github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+
+	"github.com/ulule/limiter/v3"
+)
+
+// FailoverOptions wraps the subset of go-redis Sentinel options needed to
+// build a Store backed by a Redis Sentinel deployment.
+type FailoverOptions struct {
+	// MasterName is the name of the master monitored by the Sentinels.
+	MasterName string
+	// SentinelAddrs is the list of Sentinel addresses, e.g. "host:26379".
+	SentinelAddrs []string
+	// SentinelPassword is the password used to authenticate against the
+	// Sentinels, if any.
+	SentinelPassword string
+	// Password is the password used to authenticate against the master and
+	// replicas, if any.
+	Password string
+	// DB is the redis database to select.
+	DB int
+}
+
+// NewFailoverStore returns an instance of redis store backed by a Redis
+// Sentinel deployment, using redis.NewFailoverClient to follow master
+// failovers transparently.
+func NewFailoverStore(opts FailoverOptions, storeOpts limiter.StoreOptions, options ...Option) (limiter.Store, error) {
+	client := libredis.NewFailoverClient(&libredis.FailoverOptions{
+		MasterName:       opts.MasterName,
+		SentinelAddrs:    opts.SentinelAddrs,
+		SentinelPassword: opts.SentinelPassword,
+		Password:         opts.Password,
+		DB:               opts.DB,
+	})
+
+	return NewStoreWithOptions(client, storeOpts, options...)
+}
+
+// ClusterOptions wraps the subset of go-redis Cluster options needed to
+// build a Store backed by a Redis Cluster deployment, plus the hash-tag
+// enforced on every key built by the store.
+type ClusterOptions struct {
+	// Addrs is the list of cluster node addresses, e.g. "host:6379".
+	Addrs []string
+	// Password is the password used to authenticate against every node, if
+	// any.
+	Password string
+	// KeyTag is the hash tag NewClusterStore wraps every key in via
+	// KeyBuilder (e.g. prefix "limiter" and KeyTag "tenant-42" produce keys
+	// like "{tenant-42}:limiter:some-key").
+	KeyTag string
+}
+
+// NewClusterStore returns an instance of redis store backed by a Redis
+// Cluster deployment, using redis.NewClusterClient. ClusterOptions.KeyTag
+// is required and returns an explicit error if empty; see KeyBuilder for
+// why. Its KeyBuilder is appended after any options passed in, so it always
+// wins over a caller-supplied WithKeyBuilder. A caller-supplied
+// WithKeyPrefixFunc still picks the prefix, which the hash tag then wraps.
+func NewClusterStore(opts ClusterOptions, storeOpts limiter.StoreOptions, options ...Option) (limiter.Store, error) {
+	if opts.KeyTag == "" {
+		return nil, errors.New("limiter: ClusterOptions.KeyTag is required to guarantee single-slot keys")
+	}
+
+	client := libredis.NewClusterClient(&libredis.ClusterOptions{
+		Addrs:    opts.Addrs,
+		Password: opts.Password,
+	})
+
+	tag := opts.KeyTag
+	options = append(options, WithKeyBuilder(func(prefix, key string) string {
+		return fmt.Sprintf("{%s}:%s:%s", tag, prefix, key)
+	}))
+
+	return NewStoreWithOptions(client, storeOpts, options...)
+}
+
+// Ping checks connectivity to the redis server(s) backing the store. It
+// returns an error if the underlying client doesn't expose a Ping command,
+// or if the ping itself fails.
+func (store *Store) Ping(ctx context.Context) error {
+	pinger, ok := store.client.(interface {
+		Ping(ctx context.Context) *libredis.StatusCmd
+	})
+	if !ok {
+		return errors.New("limiter: underlying redis client does not support Ping")
+	}
+
+	return pinger.Ping(ctx).Err()
+}
+
+// StartHealthCheck launches a background goroutine that calls Ping on the
+// given interval until ctx is cancelled. Failures are reported through
+// onError; if onError is nil, they are logged with the standard logger.
+// Callers should cancel ctx to stop the goroutine.
+func (store *Store) StartHealthCheck(ctx context.Context, interval time.Duration, onError func(error)) {
+	if onError == nil {
+		onError = func(err error) {
+			log.Printf("limiter: redis health check failed: %v", err)
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := store.Ping(ctx); err != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+}
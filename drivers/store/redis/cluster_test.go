@@ -0,0 +1,46 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/ulule/limiter/v3"
+)
+
+func TestNewClusterStoreRequiresKeyTag(t *testing.T) {
+	_, err := NewClusterStore(ClusterOptions{Addrs: []string{"127.0.0.1:7000"}}, limiter.StoreOptions{
+		Prefix: limiter.DefaultPrefix,
+	})
+	if err == nil {
+		t.Fatal("expected an error when ClusterOptions.KeyTag is empty")
+	}
+}
+
+func TestNewClusterStoreSucceedsWithKeyTag(t *testing.T) {
+	store, err := NewClusterStore(ClusterOptions{
+		Addrs:  []string{"127.0.0.1:7000"},
+		KeyTag: "tenant-42",
+	}, limiter.StoreOptions{
+		Prefix: limiter.DefaultPrefix,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store == nil {
+		t.Fatal("expected a non-nil store")
+	}
+}
+
+func TestNewFailoverStoreSucceeds(t *testing.T) {
+	store, err := NewFailoverStore(FailoverOptions{
+		MasterName:    "mymaster",
+		SentinelAddrs: []string{"127.0.0.1:26379"},
+	}, limiter.StoreOptions{
+		Prefix: limiter.DefaultPrefix,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store == nil {
+		t.Fatal("expected a non-nil store")
+	}
+}
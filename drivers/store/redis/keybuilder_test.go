@@ -0,0 +1,59 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ulule/limiter/v3"
+)
+
+func TestBuildKeyDefault(t *testing.T) {
+	store := &Store{Prefix: "limiter", KeyBuilder: defaultKeyBuilder}
+
+	got := store.buildKey(context.Background(), "some-key")
+	want := "limiter:some-key"
+	if got != want {
+		t.Fatalf("buildKey() = %q, want %q", got, want)
+	}
+}
+
+func TestBuildKeyWithPrefixFunc(t *testing.T) {
+	store := &Store{
+		Prefix:     "limiter",
+		KeyBuilder: defaultKeyBuilder,
+		KeyPrefixFunc: func(ctx context.Context) string {
+			return "tenant-7"
+		},
+	}
+
+	got := store.buildKey(context.Background(), "some-key")
+	want := "tenant-7:some-key"
+	if got != want {
+		t.Fatalf("buildKey() = %q, want %q", got, want)
+	}
+}
+
+// TestClusterKeyBuilderWinsOverKeyPrefixFunc verifies that a KeyPrefixFunc
+// passed into NewClusterStore can't bypass the hash-tag guarantee the
+// cluster store exists to provide: the final key is still wrapped in the
+// configured KeyTag, no matter what prefix a request resolves to.
+func TestClusterKeyBuilderWinsOverKeyPrefixFunc(t *testing.T) {
+	storeIface, err := NewClusterStore(ClusterOptions{
+		Addrs:  []string{"127.0.0.1:7000"},
+		KeyTag: "tenant-42",
+	}, limiter.StoreOptions{
+		Prefix: "limiter",
+	}, WithKeyPrefixFunc(func(ctx context.Context) string {
+		return "untagged-prefix"
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	store := storeIface.(*Store)
+	got := store.buildKey(context.Background(), "some-key")
+	if !strings.HasPrefix(got, "{tenant-42}:") {
+		t.Fatalf("buildKey() = %q, want it wrapped in the {tenant-42} hash tag", got)
+	}
+}
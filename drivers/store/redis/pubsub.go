@@ -0,0 +1,165 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Event describes a change to a rate-limited key, published over Redis
+// PubSub whenever Reset is called or a request crosses its limit, so that
+// every Store instance sharing the same Redis backend can react (e.g.
+// invalidate a local cache such as the tiered store, or emit metrics).
+type Event struct {
+	Key    string    `json:"key"`
+	Count  int64     `json:"count"`
+	Limit  int64     `json:"limit"`
+	Time   time.Time `json:"ts"`
+	Action string    `json:"action"`
+}
+
+// eventChannel returns the PubSub channel a given (already prefixed) key
+// publishes its events on.
+func eventChannel(key string) string {
+	return "limiter:events:" + key
+}
+
+// publishEvent publishes an Event for key on its channel. Publish failures
+// are ignored: they must never affect the outcome of the rate-limit
+// decision that triggered them.
+func (store *Store) publishEvent(ctx context.Context, key string, action string, count, limit int64) {
+	payload, err := json.Marshal(Event{
+		Key:    key,
+		Count:  count,
+		Limit:  limit,
+		Time:   time.Now(),
+		Action: action,
+	})
+	if err != nil {
+		return
+	}
+
+	store.client.Publish(ctx, eventChannel(key), payload)
+}
+
+// Subscribe returns a channel of Events published for the given keys,
+// either by Reset or by a request crossing its limit. The channel is closed
+// once ctx is cancelled.
+func (store *Store) Subscribe(ctx context.Context, keys ...string) (<-chan Event, error) {
+	patterns := make([]string, 0, len(keys))
+	for _, key := range keys {
+		patterns = append(patterns, eventChannel(store.buildKey(ctx, key)))
+	}
+
+	return store.subscribe(ctx, patterns...)
+}
+
+// SubscribeAll returns a channel of Events for every key sharing this
+// store's prefix.
+func (store *Store) SubscribeAll(ctx context.Context) (<-chan Event, error) {
+	return store.subscribe(ctx, eventChannel(store.keyPattern()))
+}
+
+// keyPattern returns a PSubscribe-style glob matching every key this store
+// builds from its static Prefix, routed through KeyBuilder so it reflects
+// any hash-tag wrapping a custom builder adds (e.g. NewClusterStore's
+// KeyTag). It does not account for a KeyPrefixFunc deriving a different
+// prefix per request: callers relying on per-tenant prefixes should use
+// Subscribe with the known set of keys instead of SubscribeAll.
+func (store *Store) keyPattern() string {
+	return store.KeyBuilder(store.Prefix, "*")
+}
+
+// subscribe runs a PSubscribe against the given patterns and decodes every
+// message received into an Event, dropping any message that doesn't parse.
+func (store *Store) subscribe(ctx context.Context, patterns ...string) (<-chan Event, error) {
+	pubsub := store.client.PSubscribe(ctx, patterns...)
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return nil, errors.Wrap(err, "limiter: cannot subscribe to events")
+	}
+
+	events := make(chan Event)
+
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+
+				var event Event
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					continue
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// SubscribeKeyspaceExpirations returns a channel of Events derived from
+// Redis keyspace notifications, emitting one whenever a counter key under
+// this store's prefix expires. It requires the server to be configured
+// with `notify-keyspace-events` including at least "Ex" (expired events).
+func (store *Store) SubscribeKeyspaceExpirations(ctx context.Context, db int) (<-chan Event, error) {
+	keyspacePrefix := fmt.Sprintf("__keyspace@%d__:", db)
+	pubsub := store.client.PSubscribe(ctx, keyspacePrefix+store.keyPattern())
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return nil, errors.Wrap(err, "limiter: cannot subscribe to keyspace notifications")
+	}
+
+	events := make(chan Event)
+
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+
+				if msg.Payload != "expired" {
+					continue
+				}
+
+				select {
+				case events <- Event{
+					Key:    strings.TrimPrefix(msg.Channel, keyspacePrefix),
+					Action: "expired",
+					Time:   time.Now(),
+				}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
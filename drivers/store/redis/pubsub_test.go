@@ -0,0 +1,33 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestKeyPatternUsesKeyBuilder verifies that SubscribeAll matches the
+// channel names publishEvent actually uses, and SubscribeKeyspaceExpirations
+// the keys the store actually builds, even when a custom KeyBuilder wraps
+// keys in a Redis Cluster hash tag: both derive their pattern from
+// store.keyPattern, which goes through KeyBuilder rather than raw Prefix
+// concatenation.
+func TestKeyPatternUsesKeyBuilder(t *testing.T) {
+	store := &Store{
+		Prefix: "limiter",
+		KeyBuilder: func(prefix, key string) string {
+			return "{tenant-42}:" + prefix + ":" + key
+		},
+	}
+
+	got := store.keyPattern()
+	want := "{tenant-42}:limiter:*"
+	if got != want {
+		t.Fatalf("keyPattern() = %q, want %q", got, want)
+	}
+
+	channel := eventChannel(store.buildKey(context.Background(), "some-key"))
+	if !strings.HasPrefix(channel, "limiter:events:{tenant-42}:limiter:") {
+		t.Fatalf("publishEvent channel %q doesn't share the keyPattern() prefix", channel)
+	}
+}
@@ -3,6 +3,10 @@ package redis
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	libredis "github.com/go-redis/redis/v8"
@@ -20,6 +24,27 @@ type Client interface {
 	Del(ctx context.Context, keys ...string) *libredis.IntCmd
 	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *libredis.BoolCmd
 	Eval(ctx context.Context, script string, keys []string, args ...interface{}) *libredis.Cmd
+	EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *libredis.Cmd
+	ScriptLoad(ctx context.Context, script string) *libredis.StringCmd
+	Publish(ctx context.Context, channel string, message interface{}) *libredis.IntCmd
+	PSubscribe(ctx context.Context, channels ...string) *libredis.PubSub
+}
+
+// KeyBuilder builds the final redis key from a prefix and a key. The default
+// reproduces the store's historical "prefix:key" format; a Redis Cluster
+// deployment supplies one that wraps every key in a hash tag instead, so
+// that WATCH and the Lua-script algorithms always operate on a single slot
+// (see ClusterOptions.KeyTag).
+type KeyBuilder func(prefix, key string) string
+
+// KeyPrefixFunc derives the prefix to use for a given request, allowing a
+// single Store to be shared across multiple logical namespaces (e.g.
+// per-tenant) without instantiating one Store per prefix.
+type KeyPrefixFunc func(ctx context.Context) string
+
+// defaultKeyBuilder reproduces the store's historical "prefix:key" format.
+func defaultKeyBuilder(prefix, key string) string {
+	return fmt.Sprintf("%s:%s", prefix, key)
 }
 
 // Store is the redis store.
@@ -28,8 +53,62 @@ type Store struct {
 	Prefix string
 	// MaxRetry is the maximum number of retry under race conditions.
 	MaxRetry int
+	// Algorithm selects which rate-limiting algorithm is used to evaluate
+	// requests. It defaults to FixedWindow, which preserves the historical
+	// WATCH/MULTI behavior of this store.
+	Algorithm Algorithm
+	// KeyBuilder builds the final redis key from a prefix and a key.
+	// Defaults to defaultKeyBuilder.
+	KeyBuilder KeyBuilder
+	// KeyPrefixFunc, if set, derives the prefix to use for a given request
+	// instead of the fixed Prefix field.
+	KeyPrefixFunc KeyPrefixFunc
 	// client used to communicate with redis server.
 	client Client
+	// scripts holds the SHA1 digests of the Lua scripts backing
+	// SlidingWindow and TokenBucket/GCRA, loaded lazily on first use.
+	scripts scriptSHAs
+	// seq is incremented on every SlidingWindow request to make each
+	// ZADD member unique, even when two requests land on the same
+	// nanosecond.
+	seq uint64
+}
+
+// scriptSHAs caches the SHA1 digests returned by SCRIPT LOAD so that
+// subsequent calls can use EVALSHA instead of re-sending the script body.
+// A Store is shared across concurrent callers (e.g. every HTTP handler in a
+// service calling Get at once), so mu guards the lazy load of each digest.
+type scriptSHAs struct {
+	mu            sync.Mutex
+	slidingWindow string
+	gcra          string
+	incrBy        string
+}
+
+// Option changes the behavior of NewStoreWithOptions.
+type Option func(*Store)
+
+// WithAlgorithm sets the rate-limiting algorithm used by the store.
+func WithAlgorithm(algorithm Algorithm) Option {
+	return func(store *Store) {
+		store.Algorithm = algorithm
+	}
+}
+
+// WithKeyBuilder overrides how the store turns a prefix and a key into the
+// final redis key.
+func WithKeyBuilder(builder KeyBuilder) Option {
+	return func(store *Store) {
+		store.KeyBuilder = builder
+	}
+}
+
+// WithKeyPrefixFunc makes the store derive its prefix per-request instead
+// of using the fixed Prefix field.
+func WithKeyPrefixFunc(fn KeyPrefixFunc) Option {
+	return func(store *Store) {
+		store.KeyPrefixFunc = fn
+	}
 }
 
 // NewStore returns an instance of redis store with defaults.
@@ -42,23 +121,54 @@ func NewStore(client Client) (limiter.Store, error) {
 }
 
 // NewStoreWithOptions returns an instance of redis store with options.
-func NewStoreWithOptions(client Client, options limiter.StoreOptions) (limiter.Store, error) {
+func NewStoreWithOptions(client Client, options limiter.StoreOptions, opts ...Option) (limiter.Store, error) {
 	store := &Store{
-		client:   client,
-		Prefix:   options.Prefix,
-		MaxRetry: options.MaxRetry,
+		client:     client,
+		Prefix:     options.Prefix,
+		MaxRetry:   options.MaxRetry,
+		KeyBuilder: defaultKeyBuilder,
 	}
 
 	if store.MaxRetry <= 0 {
 		store.MaxRetry = 1
 	}
 
+	for _, opt := range opts {
+		opt(store)
+	}
+
 	return store, nil
 }
 
+// buildKey resolves the prefix to use for this request (via KeyPrefixFunc,
+// falling back to the fixed Prefix field) and builds the final redis key
+// through KeyBuilder.
+func (store *Store) buildKey(ctx context.Context, key string) string {
+	prefix := store.Prefix
+	if store.KeyPrefixFunc != nil {
+		prefix = store.KeyPrefixFunc(ctx)
+	}
+
+	return store.KeyBuilder(prefix, key)
+}
+
 // Get returns the limit for given identifier.
 func (store *Store) Get(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
-	key = fmt.Sprintf("%s:%s", store.Prefix, key)
+	key = store.buildKey(ctx, key)
+
+	switch store.Algorithm {
+	case SlidingWindow:
+		return store.getSlidingWindow(ctx, key, rate)
+	case TokenBucket:
+		return store.getGCRA(ctx, key, rate)
+	default:
+		return store.getFixedWindow(ctx, key, rate)
+	}
+}
+
+// getFixedWindow implements Get using the historical WATCH/MULTI optimistic
+// locking counter (the FixedWindow algorithm).
+func (store *Store) getFixedWindow(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
 	now := time.Now()
 
 	lctx := limiter.Context{}
@@ -85,6 +195,10 @@ func (store *Store) Get(ctx context.Context, key string, rate limiter.Rate) (lim
 			expiration = now.Add(ttl)
 		}
 
+		if count > rate.Limit {
+			store.publishEvent(ctx, key, "limit_reached", count, rate.Limit)
+		}
+
 		lctx = common.GetContextFromState(now, rate, expiration, count)
 		return nil
 	}
@@ -98,9 +212,200 @@ func (store *Store) Get(ctx context.Context, key string, rate limiter.Rate) (lim
 	return lctx, nil
 }
 
+// getSlidingWindow implements Get using a single atomic Lua script that
+// maintains a ZSET of request timestamps per key, evicting entries outside
+// of the rate period before counting and recording the request.
+func (store *Store) getSlidingWindow(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	now := time.Now()
+
+	// The member must be unique per call: two requests whose time.Now()
+	// lands on the same nanosecond would otherwise share a ZADD member, so
+	// the second overwrites the first's score instead of adding an entry,
+	// silently dropping a hit from ZCARD's count.
+	member := fmt.Sprintf("%d-%d", now.UnixNano(), atomic.AddUint64(&store.seq, 1))
+
+	res, err := store.evalScript(ctx, slidingWindowScript, &store.scripts.slidingWindow, []string{key},
+		now.UnixNano(), rate.Period.Nanoseconds(), rate.Limit, member)
+	if err != nil {
+		return limiter.Context{}, errors.Wrapf(err, "limiter: cannot get value for %s", key)
+	}
+
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 3 {
+		return limiter.Context{}, errors.Errorf("limiter: unexpected sliding window script result for %s", key)
+	}
+
+	allowed, err := toInt64(values[0])
+	if err != nil {
+		return limiter.Context{}, errors.Wrapf(err, "limiter: cannot parse allowed flag for %s", key)
+	}
+
+	count, err := toInt64(values[1])
+	if err != nil {
+		return limiter.Context{}, errors.Wrapf(err, "limiter: cannot parse count for %s", key)
+	}
+
+	// The script doesn't record a rejected request, so its count stops at
+	// rate.Limit: count the rejected request itself so it reads as reached.
+	if allowed == 0 && count <= rate.Limit {
+		count = rate.Limit + 1
+	}
+
+	expiration := now.Add(rate.Period)
+	if oldest, err := toInt64(values[2]); err == nil && oldest > 0 {
+		expiration = time.Unix(0, oldest).Add(rate.Period)
+	}
+
+	if count > rate.Limit {
+		store.publishEvent(ctx, key, "limit_reached", count, rate.Limit)
+	}
+
+	return common.GetContextFromState(now, rate, expiration, count), nil
+}
+
+// getGCRA implements Get using a single atomic Lua script that computes the
+// theoretical arrival time (TAT) of the key following the Generic Cell Rate
+// Algorithm, giving token-bucket semantics without a WATCH retry loop.
+func (store *Store) getGCRA(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	now := time.Now()
+
+	// A non-positive limit means deny everything: there is no emission
+	// interval to compute (it would divide by zero), so reject outright
+	// instead of asking Redis.
+	if rate.Limit <= 0 {
+		count := int64(1)
+		store.publishEvent(ctx, key, "limit_reached", count, rate.Limit)
+		return common.GetContextFromState(now, rate, now.Add(rate.Period), count), nil
+	}
+
+	emission := rate.Period.Nanoseconds() / rate.Limit
+
+	res, err := store.evalScript(ctx, gcraScript, &store.scripts.gcra, []string{key},
+		now.UnixNano(), emission, rate.Period.Nanoseconds(), rate.Period.Nanoseconds())
+	if err != nil {
+		return limiter.Context{}, errors.Wrapf(err, "limiter: cannot get value for %s", key)
+	}
+
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 4 {
+		return limiter.Context{}, errors.Errorf("limiter: unexpected gcra script result for %s", key)
+	}
+
+	allowed, err := toInt64(values[0])
+	if err != nil {
+		return limiter.Context{}, errors.Wrapf(err, "limiter: cannot parse allowed flag for %s", key)
+	}
+
+	remaining, err := toInt64(values[1])
+	if err != nil {
+		return limiter.Context{}, errors.Wrapf(err, "limiter: cannot parse remaining for %s", key)
+	}
+
+	resetMs, err := toInt64(values[3])
+	if err != nil {
+		return limiter.Context{}, errors.Wrapf(err, "limiter: cannot parse reset for %s", key)
+	}
+
+	// remaining already reflects tat having been advanced for this request
+	// when allowed == 1, so rate.Limit - remaining is the count including
+	// the current request: no further adjustment needed.
+	count := rate.Limit - remaining
+
+	if allowed == 0 {
+		// A rejected request doesn't advance tat, so remaining is 0 and
+		// count stops at rate.Limit: count the rejected request itself so
+		// it reads as reached.
+		count = rate.Limit + 1
+		store.publishEvent(ctx, key, "limit_reached", count, rate.Limit)
+	}
+
+	expiration := now.Add(time.Duration(resetMs) * time.Millisecond)
+	return common.GetContextFromState(now, rate, expiration, count), nil
+}
+
+// IncrBy atomically adds delta to the counter identified by key, setting an
+// expiration of period if the key didn't already exist, and returns the
+// updated count. It lets callers coalesce several hits into a single
+// round-trip instead of calling Get once per hit.
+func (store *Store) IncrBy(ctx context.Context, key string, delta int64, period time.Duration) (int64, error) {
+	key = store.buildKey(ctx, key)
+
+	res, err := store.evalScript(ctx, incrByScript, &store.scripts.incrBy, []string{key},
+		delta, period.Milliseconds())
+	if err != nil {
+		return 0, errors.Wrapf(err, "limiter: cannot incrby value for %s", key)
+	}
+
+	count, err := toInt64(res)
+	if err != nil {
+		return 0, errors.Wrapf(err, "limiter: cannot parse incrby result for %s", key)
+	}
+
+	return count, nil
+}
+
+// evalScript runs the given Lua script via EVALSHA, loading it with SCRIPT
+// LOAD and caching its digest in sha on first use, then falling back to a
+// plain EVAL if the server reports NOSCRIPT (e.g. after a Redis restart or
+// FLUSHALL cleared the script cache).
+func (store *Store) evalScript(ctx context.Context, script string, sha *string,
+	keys []string, args ...interface{}) (interface{}, error) {
+
+	digest, err := store.loadScript(ctx, script, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := store.client.EvalSha(ctx, digest, keys, args...).Result()
+	if err != nil && isNoScriptErr(err) {
+		res, err = store.client.Eval(ctx, script, keys, args...).Result()
+	}
+
+	return res, err
+}
+
+// loadScript returns the cached SHA1 digest for script, loading it with
+// SCRIPT LOAD on first use. store.scripts.mu serializes this lazy load
+// across concurrent callers so that two goroutines racing on the same
+// not-yet-loaded script can't both issue SCRIPT LOAD and clobber *sha.
+func (store *Store) loadScript(ctx context.Context, script string, sha *string) (string, error) {
+	store.scripts.mu.Lock()
+	defer store.scripts.mu.Unlock()
+
+	if *sha == "" {
+		digest, err := store.client.ScriptLoad(ctx, script).Result()
+		if err != nil {
+			return "", err
+		}
+		*sha = digest
+	}
+
+	return *sha, nil
+}
+
+// isNoScriptErr returns whether err is a Redis NOSCRIPT error, meaning the
+// script isn't cached on the server side and must be sent again with EVAL.
+func isNoScriptErr(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT")
+}
+
+// toInt64 converts a Lua script reply element (an int64 or a string, as
+// returned for table values larger than what Lua's number type can encode)
+// into an int64.
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, errors.Errorf("unsupported script reply type %T", value)
+	}
+}
+
 // Peek returns the limit for given identifier, without modification on current values.
 func (store *Store) Peek(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
-	key = fmt.Sprintf("%s:%s", store.Prefix, key)
+	key = store.buildKey(ctx, key)
 	now := time.Now()
 
 	lctx := limiter.Context{}
@@ -130,7 +435,7 @@ func (store *Store) Peek(ctx context.Context, key string, rate limiter.Rate) (li
 
 // Reset returns the limit for given identifier which is set to zero.
 func (store *Store) Reset(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
-	key = fmt.Sprintf("%s:%s", store.Prefix, key)
+	key = store.buildKey(ctx, key)
 	now := time.Now()
 
 	lctx := limiter.Context{}
@@ -144,6 +449,8 @@ func (store *Store) Reset(ctx context.Context, key string, rate limiter.Rate) (l
 		count := int64(0)
 		expiration := now.Add(rate.Period)
 
+		store.publishEvent(ctx, key, "reset", count, rate.Limit)
+
 		lctx = common.GetContextFromState(now, rate, expiration, count)
 		return nil
 	}
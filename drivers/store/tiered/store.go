@@ -0,0 +1,178 @@
+package tiered
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+
+	"github.com/ulule/limiter/v3"
+	"github.com/ulule/limiter/v3/drivers/store/common"
+)
+
+// Incrementer is implemented by stores that can atomically add delta to a
+// key's counter within a single round-trip, returning the updated count.
+// Store falls back to a loop of delta calls to the backing store's Get when
+// it isn't implemented.
+type Incrementer interface {
+	IncrBy(ctx context.Context, key string, delta int64, period time.Duration) (int64, error)
+}
+
+// Options configures a Store.
+type Options struct {
+	// Capacity is the maximum number of keys held in the local cache.
+	Capacity int
+	// FlushInterval is the time since a key's last flush after which its
+	// next Get flushes the local hits to the backing store. Flushes only
+	// happen inside Get: hits on a key that gets no further Get stay
+	// unflushed.
+	FlushInterval time.Duration
+	// FlushThreshold is the number of local hits on a key that triggers an
+	// immediate flush to the backing store, regardless of FlushInterval.
+	FlushThreshold int64
+}
+
+// DefaultOptions used for NewStore.
+var DefaultOptions = Options{
+	Capacity:       4096,
+	FlushInterval:  100 * time.Millisecond,
+	FlushThreshold: 100,
+}
+
+// Store is a limiter.Store that fronts another Store with a bounded
+// in-process LRU cache, coalescing hits on hot keys into periodic batched
+// writes to the backing store instead of one round-trip per request. This
+// trades a small amount of over-limit slack, bounded by FlushInterval and
+// FlushThreshold, for much lower load on the backing store under high QPS.
+type Store struct {
+	backing limiter.Store
+	options Options
+	cache   *lru.Cache
+	// creationMu serializes the get-or-create sequence below, so that two
+	// concurrent first-hits on the same cold or evicted key can't each
+	// build and install their own *counter: whichever lost that race would
+	// silently discard its recorded hit, undercounting requests.
+	creationMu sync.Mutex
+}
+
+// NewStore returns an instance of tiered store with default options.
+func NewStore(backing limiter.Store) (limiter.Store, error) {
+	return NewStoreWithOptions(backing, DefaultOptions)
+}
+
+// NewStoreWithOptions returns an instance of tiered store with options.
+func NewStoreWithOptions(backing limiter.Store, options Options) (limiter.Store, error) {
+	cache, err := lru.New(options.Capacity)
+	if err != nil {
+		return nil, errors.Wrap(err, "limiter: cannot create local cache")
+	}
+
+	return &Store{
+		backing: backing,
+		options: options,
+		cache:   cache,
+	}, nil
+}
+
+// counter tracks the hits accumulated locally for a key since the last
+// flush to the backing store, plus the count as of that last flush.
+type counter struct {
+	mu        sync.Mutex
+	base      int64
+	delta     int64
+	lastFlush time.Time
+}
+
+// Get increments the local counter for key and returns an estimated
+// context computed from it. Once FlushThreshold local hits accumulate, or
+// FlushInterval has elapsed since the last flush, the accumulated delta is
+// coalesced into a single call to the backing store.
+func (store *Store) Get(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	now := time.Now()
+
+	entry := store.getOrCreateCounter(key)
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	entry.delta++
+
+	needsFlush := entry.delta >= store.options.FlushThreshold ||
+		now.Sub(entry.lastFlush) >= store.options.FlushInterval
+
+	if !needsFlush {
+		count := entry.base + entry.delta
+		return common.GetContextFromState(now, rate, now.Add(rate.Period), count), nil
+	}
+
+	count, err := store.flush(ctx, key, entry.delta, rate)
+	if err != nil {
+		return limiter.Context{}, err
+	}
+
+	entry.base = count
+	entry.delta = 0
+	entry.lastFlush = now
+
+	return common.GetContextFromState(now, rate, now.Add(rate.Period), count), nil
+}
+
+// getOrCreateCounter returns the *counter for key, creating and installing
+// one if none exists yet. The cache lookup is retried under creationMu so
+// that at most one *counter is ever created per key, even when several
+// goroutines race on the same cold or newly-evicted key.
+func (store *Store) getOrCreateCounter(key string) *counter {
+	if value, ok := store.cache.Get(key); ok {
+		return value.(*counter)
+	}
+
+	store.creationMu.Lock()
+	defer store.creationMu.Unlock()
+
+	if value, ok := store.cache.Get(key); ok {
+		return value.(*counter)
+	}
+
+	// lastFlush starts at creation time, not the zero value: otherwise the
+	// very first hit on a fresh key would look overdue for a flush (elapsed
+	// time since the zero Time is enormous) and bypass the coalescing this
+	// store exists to provide.
+	entry := &counter{lastFlush: time.Now()}
+	store.cache.Add(key, entry)
+	return entry
+}
+
+// flush coalesces delta hits on key into the backing store, using its
+// IncrBy method when available, or a loop of delta Get calls otherwise.
+func (store *Store) flush(ctx context.Context, key string, delta int64, rate limiter.Rate) (int64, error) {
+	if incr, ok := store.backing.(Incrementer); ok {
+		return incr.IncrBy(ctx, key, delta, rate.Period)
+	}
+
+	var lctx limiter.Context
+	for i := int64(0); i < delta; i++ {
+		var err error
+		lctx, err = store.backing.Get(ctx, key, rate)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return lctx.Limit - lctx.Remaining, nil
+}
+
+// Peek returns the limit for given identifier, without modification on
+// current values. It reflects the backing store's last flushed count: hits
+// accumulated locally since the last flush are not yet visible.
+func (store *Store) Peek(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	return store.backing.Peek(ctx, key, rate)
+}
+
+// Reset clears the local counter for key and resets it on the backing
+// store.
+func (store *Store) Reset(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	store.cache.Remove(key)
+	return store.backing.Reset(ctx, key, rate)
+}
@@ -0,0 +1,144 @@
+package tiered
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ulule/limiter/v3"
+)
+
+// fakeBackingStore is a minimal limiter.Store used to test Store in
+// isolation. It counts hits per key so tests can assert exactly one hit is
+// recorded per call, with no duplicates and no losses.
+type fakeBackingStore struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func newFakeBackingStore() *fakeBackingStore {
+	return &fakeBackingStore{counts: map[string]int64{}}
+}
+
+func (s *fakeBackingStore) Get(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counts[key]++
+	count := s.counts[key]
+
+	return limiter.Context{Limit: rate.Limit, Remaining: rate.Limit - count}, nil
+}
+
+func (s *fakeBackingStore) Peek(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := s.counts[key]
+	return limiter.Context{Limit: rate.Limit, Remaining: rate.Limit - count}, nil
+}
+
+func (s *fakeBackingStore) Reset(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.counts, key)
+	return limiter.Context{Limit: rate.Limit, Remaining: rate.Limit}, nil
+}
+
+func (s *fakeBackingStore) count(key string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counts[key]
+}
+
+func TestStoreConcurrentFirstHitsDoNotLoseCounts(t *testing.T) {
+	backing := newFakeBackingStore()
+	store, err := NewStoreWithOptions(backing, Options{
+		Capacity:       1024,
+		FlushInterval:  time.Millisecond,
+		FlushThreshold: 1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rate := limiter.Rate{Period: time.Minute, Limit: 1000}
+	key := "hot-key"
+
+	const hits = 100
+	var wg sync.WaitGroup
+	for i := 0; i < hits; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := store.Get(context.Background(), key, rate); err != nil {
+				t.Errorf("Get: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := backing.count(key); got != hits {
+		t.Fatalf("backing store recorded %d hits, want %d: some concurrent first-hits were dropped", got, hits)
+	}
+}
+
+func TestStoreFlushesOnThreshold(t *testing.T) {
+	backing := newFakeBackingStore()
+	store, err := NewStoreWithOptions(backing, Options{
+		Capacity:       1024,
+		FlushInterval:  time.Hour,
+		FlushThreshold: 3,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rate := limiter.Rate{Period: time.Minute, Limit: 1000}
+	key := "key"
+
+	for i := 0; i < 2; i++ {
+		if _, err := store.Get(context.Background(), key, rate); err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+	}
+	if got := backing.count(key); got != 0 {
+		t.Fatalf("backing store should not be hit before FlushThreshold, got %d hits", got)
+	}
+
+	if _, err := store.Get(context.Background(), key, rate); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got := backing.count(key); got != 3 {
+		t.Fatalf("backing store should coalesce the 3 local hits into one flush, got %d hits", got)
+	}
+}
+
+func TestStoreResetClearsLocalCounter(t *testing.T) {
+	backing := newFakeBackingStore()
+	store, err := NewStoreWithOptions(backing, Options{
+		Capacity:       1024,
+		FlushInterval:  time.Hour,
+		FlushThreshold: 1000,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rate := limiter.Rate{Period: time.Minute, Limit: 1000}
+	key := "key"
+
+	if _, err := store.Get(context.Background(), key, rate); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if _, err := store.Reset(context.Background(), key, rate); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+
+	ts := store.(*Store)
+	if _, ok := ts.cache.Get(key); ok {
+		t.Fatal("expected Reset to remove the local counter")
+	}
+}